@@ -0,0 +1,238 @@
+// Package aggregator rolls up counter, gauge, and timer envelopes into
+// periodic summary envelopes, so the CLI can be used as a lightweight
+// top-like tool without a metrics backend.
+package aggregator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+)
+
+// Func names one of the rollup functions a window can compute.
+type Func string
+
+const (
+	FuncSum  Func = "sum"
+	FuncRate Func = "rate"
+	FuncMin  Func = "min"
+	FuncMax  Func = "max"
+	FuncAvg  Func = "avg"
+	FuncP50  Func = "p50"
+	FuncP95  Func = "p95"
+	FuncP99  Func = "p99"
+)
+
+// Aggregator accumulates counter, gauge, and timer envelopes keyed by
+// (source_id, instance_id, name, tags) over a window, and on Flush emits
+// one rolled-up envelope per key per requested Func.
+type Aggregator struct {
+	window time.Duration
+	funcs  []Func
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// New returns an Aggregator that rolls up over window using funcs. Funcs
+// that don't apply to a given envelope kind (e.g. FuncP99 on a counter) are
+// ignored for that kind.
+func New(window time.Duration, funcs []Func) *Aggregator {
+	return &Aggregator{
+		window: window,
+		funcs:  funcs,
+		series: map[string]*series{},
+	}
+}
+
+type series struct {
+	sourceID   string
+	instanceID string
+	name       string
+	tags       map[string]string
+
+	isCounter bool
+	counter   float64
+
+	isGaugeOrTimer bool
+	unit           string
+	samples        []float64
+}
+
+// Add folds a counter, gauge, or timer envelope into the current window.
+// Log and event envelopes are ignored; callers should pass those straight
+// through to the formatter instead.
+func (a *Aggregator) Add(e *loggregator_v2.Envelope) {
+	switch msg := e.GetMessage().(type) {
+	case *loggregator_v2.Envelope_Counter:
+		s := a.seriesFor(e, msg.Counter.GetName())
+		s.isCounter = true
+		s.counter += float64(msg.Counter.GetDelta())
+	case *loggregator_v2.Envelope_Gauge:
+		for name, metric := range msg.Gauge.GetMetrics() {
+			s := a.seriesFor(e, name)
+			s.isGaugeOrTimer = true
+			s.unit = metric.GetUnit()
+			s.samples = append(s.samples, metric.GetValue())
+		}
+	case *loggregator_v2.Envelope_Timer:
+		s := a.seriesFor(e, msg.Timer.GetName())
+		s.isGaugeOrTimer = true
+		s.unit = "ms"
+		dur := float64(msg.Timer.GetStop()-msg.Timer.GetStart()) / float64(time.Millisecond)
+		s.samples = append(s.samples, dur)
+	}
+}
+
+func (a *Aggregator) seriesFor(e *loggregator_v2.Envelope, name string) *series {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := seriesKey(e.GetSourceId(), e.GetInstanceId(), name, e.GetTags())
+	s, ok := a.series[key]
+	if !ok {
+		s = &series{
+			sourceID:   e.GetSourceId(),
+			instanceID: e.GetInstanceId(),
+			name:       name,
+			tags:       e.GetTags(),
+		}
+		a.series[key] = s
+	}
+	return s
+}
+
+func seriesKey(sourceID, instanceID, name string, tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s", sourceID, instanceID, name)
+	for _, k := range names {
+		fmt.Fprintf(&b, "|%s=%s", k, tags[k])
+	}
+	return b.String()
+}
+
+// Flush emits one envelope per series per requested Func, using the
+// samples accumulated since the last Flush, and resets all series.
+func (a *Aggregator) Flush() []*loggregator_v2.Envelope {
+	a.mu.Lock()
+	seriesToFlush := a.series
+	a.series = map[string]*series{}
+	a.mu.Unlock()
+
+	var out []*loggregator_v2.Envelope
+	now := time.Now().UnixNano()
+
+	for _, s := range seriesToFlush {
+		for _, fn := range a.funcs {
+			value, unit, ok := s.compute(fn, a.window)
+			if !ok {
+				continue
+			}
+			out = append(out, &loggregator_v2.Envelope{
+				Timestamp:  now,
+				SourceId:   s.sourceID,
+				InstanceId: s.instanceID,
+				Tags:       s.tags,
+				Message: &loggregator_v2.Envelope_Gauge{
+					Gauge: &loggregator_v2.Gauge{
+						Metrics: map[string]*loggregator_v2.GaugeValue{
+							fmt.Sprintf("%s.%s", s.name, fn): {Value: value, Unit: unit},
+						},
+					},
+				},
+			})
+		}
+	}
+
+	return out
+}
+
+func (s *series) compute(fn Func, window time.Duration) (value float64, unit string, ok bool) {
+	if s.isCounter {
+		switch fn {
+		case FuncSum:
+			return s.counter, "", true
+		case FuncRate:
+			return s.counter / window.Seconds(), "/s", true
+		default:
+			return 0, "", false
+		}
+	}
+
+	if !s.isGaugeOrTimer || len(s.samples) == 0 {
+		return 0, "", false
+	}
+
+	switch fn {
+	case FuncMin:
+		return minOf(s.samples), s.unit, true
+	case FuncMax:
+		return maxOf(s.samples), s.unit, true
+	case FuncAvg:
+		return avgOf(s.samples), s.unit, true
+	case FuncP50:
+		return percentile(s.samples, 0.50), s.unit, true
+	case FuncP95:
+		return percentile(s.samples, 0.95), s.unit, true
+	case FuncP99:
+		return percentile(s.samples, 0.99), s.unit, true
+	default:
+		return 0, "", false
+	}
+}
+
+func minOf(samples []float64) float64 {
+	m := samples[0]
+	for _, v := range samples[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func maxOf(samples []float64) float64 {
+	m := samples[0]
+	for _, v := range samples[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func avgOf(samples []float64) float64 {
+	var sum float64
+	for _, v := range samples {
+		sum += v
+	}
+	return sum / float64(len(samples))
+}
+
+// percentile computes the nearest-rank p (0-1) percentile over samples.
+// Every sample of the window is buffered and sorted at flush time, so the
+// result is exact; no sampling or sketching is applied.
+func percentile(samples []float64, p float64) float64 {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,101 @@
+package aggregator_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/aggregator"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func gaugeEnvelope(name string, value float64) *loggregator_v2.Envelope {
+	return &loggregator_v2.Envelope{
+		SourceId: "some-source-id",
+		Message: &loggregator_v2.Envelope_Gauge{
+			Gauge: &loggregator_v2.Gauge{
+				Metrics: map[string]*loggregator_v2.GaugeValue{
+					name: {Value: value, Unit: "percentage"},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("Aggregator", func() {
+	It("computes avg and p99 within tolerance for a gauge series", func() {
+		agg := aggregator.New(0, []aggregator.Func{aggregator.FuncAvg, aggregator.FuncP99})
+
+		for i := 1; i <= 100; i++ {
+			agg.Add(gaugeEnvelope("cpu", float64(i)))
+		}
+
+		envelopes := agg.Flush()
+		values := metricValues(envelopes)
+
+		Expect(values["cpu.avg"]).To(BeNumerically("~", 50.5, 0.5))
+		Expect(values["cpu.p99"]).To(BeNumerically("~", 99, 1))
+	})
+
+	It("sums counter deltas and resets after Flush", func() {
+		agg := aggregator.New(0, []aggregator.Func{aggregator.FuncSum})
+
+		agg.Add(counterEnvelope("requests", 3))
+		agg.Add(counterEnvelope("requests", 4))
+
+		first := metricValues(agg.Flush())
+		Expect(first["requests.sum"]).To(Equal(7.0))
+
+		agg.Add(counterEnvelope("requests", 1))
+		second := metricValues(agg.Flush())
+		Expect(second["requests.sum"]).To(Equal(1.0))
+	})
+
+	It("divides the counter sum by the window to compute rate", func() {
+		agg := aggregator.New(4*time.Second, []aggregator.Func{aggregator.FuncSum, aggregator.FuncRate})
+
+		agg.Add(counterEnvelope("requests", 3))
+		agg.Add(counterEnvelope("requests", 5))
+
+		values := metricValues(agg.Flush())
+		Expect(values["requests.sum"]).To(Equal(8.0))
+		Expect(values["requests.rate"]).To(Equal(2.0))
+		Expect(values["requests.rate"]).ToNot(Equal(values["requests.sum"]))
+	})
+
+	It("passes logs through untouched by returning nothing for them", func() {
+		agg := aggregator.New(0, []aggregator.Func{aggregator.FuncAvg})
+
+		agg.Add(&loggregator_v2.Envelope{
+			Message: &loggregator_v2.Envelope_Log{
+				Log: &loggregator_v2.Log{Payload: []byte("hi")},
+			},
+		})
+
+		Expect(agg.Flush()).To(BeEmpty())
+	})
+})
+
+func counterEnvelope(name string, delta uint64) *loggregator_v2.Envelope {
+	return &loggregator_v2.Envelope{
+		SourceId: "some-source-id",
+		Message: &loggregator_v2.Envelope_Counter{
+			Counter: &loggregator_v2.Counter{Name: name, Delta: delta},
+		},
+	}
+}
+
+func metricValues(envelopes []*loggregator_v2.Envelope) map[string]float64 {
+	values := map[string]float64{}
+	for _, e := range envelopes {
+		gauge := e.GetGauge()
+		if gauge == nil {
+			continue
+		}
+		for name, metric := range gauge.GetMetrics() {
+			values[name] = metric.GetValue()
+		}
+	}
+	return values
+}
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/aggregator"
+)
+
+// ParseAggregateFlag parses the --aggregate flag shape, e.g.
+// "10s:avg,p99", into the window and funcs WithAggregate expects.
+func ParseAggregateFlag(flag string) (time.Duration, []aggregator.Func, error) {
+	windowText, funcsText, ok := strings.Cut(flag, ":")
+	if !ok || funcsText == "" {
+		return 0, nil, fmt.Errorf("command: --aggregate must look like 10s:avg,p99, got %q", flag)
+	}
+
+	window, err := time.ParseDuration(windowText)
+	if err != nil {
+		return 0, nil, fmt.Errorf("command: invalid --aggregate window %q: %s", windowText, err)
+	}
+
+	var funcs []aggregator.Func
+	for _, name := range strings.Split(funcsText, ",") {
+		funcs = append(funcs, aggregator.Func(name))
+	}
+
+	return window, funcs, nil
+}
+
+// WithAggregate replaces raw counter, gauge, and timer envelopes with
+// periodic rollups computed by funcs over window (e.g. the --aggregate
+// 10s:avg,p99 flag shape). Log envelopes always pass through unchanged.
+// See internal/aggregator for the supported funcs.
+func WithAggregate(window time.Duration, funcs []aggregator.Func) Option {
+	return func(c *config) {
+		c.aggregateWindow = window
+		c.aggregateFuncs = funcs
+	}
+}
+
+// aggregateSink routes counter, gauge, and timer envelopes into an Aggregator instead of
+// writing them immediately, and flushes the Aggregator's rollups through
+// the configured Formatter on a ticker.
+type aggregateSink struct {
+	agg    *aggregator.Aggregator
+	cfg    *config
+	writer io.Writer
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+func newAggregateSink(cfg *config, writer io.Writer) *aggregateSink {
+	sink := &aggregateSink{
+		agg:    aggregator.New(cfg.aggregateWindow, cfg.aggregateFuncs),
+		cfg:    cfg,
+		writer: writer,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go sink.run()
+	return sink
+}
+
+func (s *aggregateSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.aggregateWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *aggregateSink) flush() {
+	for _, e := range s.agg.Flush() {
+		out, err := s.cfg.formatter.Format(e)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(s.writer, "%s\n", out)
+	}
+}
+
+// handle folds a counter, gauge, or timer envelope into the aggregator and
+// reports true, so the caller skips writing it directly. Log and event
+// envelopes are reported false so the caller writes them through the
+// formatter as usual.
+func (s *aggregateSink) handle(e *loggregator_v2.Envelope) bool {
+	switch e.GetMessage().(type) {
+	case *loggregator_v2.Envelope_Log, *loggregator_v2.Envelope_Event:
+		return false
+	}
+	s.agg.Add(e)
+	return true
+}
+
+// Close stops run's ticker loop, waits for it to actually return (so its
+// goroutine can't still be inside flush when we call flush below), and then
+// flushes once more to emit any envelopes accumulated since the last tick.
+func (s *aggregateSink) Close() {
+	close(s.stopCh)
+	<-s.done
+	s.flush()
+}
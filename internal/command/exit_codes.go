@@ -0,0 +1,40 @@
+package command
+
+// Process exit codes the plugin entrypoint should return for a failed
+// StreamLogs call, distinguishing the reason a user (or a script wrapping
+// the CLI) can act on.
+const (
+	ExitOK           = 0
+	ExitAuth         = 4
+	ExitNotFound     = 5
+	ExitRateLimited  = 6
+	ExitServerError  = 7
+	ExitNetworkError = 8
+)
+
+// ExitCode maps a StreamLogs error to a process exit code. A nil err maps
+// to ExitOK; any error that isn't a *GatewayError (e.g. a dial failure)
+// maps to ExitNetworkError.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	gwErr, ok := err.(*GatewayError)
+	if !ok {
+		return ExitNetworkError
+	}
+
+	switch gwErr.Kind() {
+	case GatewayErrorAuth:
+		return ExitAuth
+	case GatewayErrorNotFound:
+		return ExitNotFound
+	case GatewayErrorRateLimited:
+		return ExitRateLimited
+	case GatewayErrorServer:
+		return ExitServerError
+	default:
+		return ExitNetworkError
+	}
+}
@@ -0,0 +1,33 @@
+package command_test
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExitCode", func() {
+	It("maps nil to ExitOK", func() {
+		Expect(command.ExitCode(nil)).To(Equal(command.ExitOK))
+	})
+
+	DescribeTable("maps GatewayError status codes to distinct exit codes",
+		func(status int, want int) {
+			err := &command.GatewayError{StatusCode: status}
+			Expect(command.ExitCode(err)).To(Equal(want))
+		},
+		Entry("401 unauthorized", http.StatusUnauthorized, command.ExitAuth),
+		Entry("403 forbidden", http.StatusForbidden, command.ExitAuth),
+		Entry("404 not found", http.StatusNotFound, command.ExitNotFound),
+		Entry("429 too many requests", http.StatusTooManyRequests, command.ExitRateLimited),
+		Entry("503 service unavailable", http.StatusServiceUnavailable, command.ExitServerError),
+	)
+
+	It("maps a non-gateway error to ExitNetworkError", func() {
+		Expect(command.ExitCode(errors.New("dial tcp: connection refused"))).To(Equal(command.ExitNetworkError))
+	})
+})
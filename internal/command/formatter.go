@@ -0,0 +1,217 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/gogo/protobuf/jsonpb"
+)
+
+// Formatter renders a single envelope for display. StreamLogs calls Format
+// once per envelope and writes the result followed by a newline.
+type Formatter interface {
+	Format(e *loggregator_v2.Envelope) ([]byte, error)
+}
+
+// WithFormatter selects how each envelope is rendered. Defaults to
+// NewJSONFormatter().
+func WithFormatter(f Formatter) Option {
+	return func(c *config) {
+		c.formatter = f
+	}
+}
+
+// NewFormatter resolves the --format flag value to a Formatter. tmpl is
+// only used when name is "template".
+func NewFormatter(name, tmpl string) (Formatter, error) {
+	switch name {
+	case "", "json":
+		return NewJSONFormatter(), nil
+	case "logfmt":
+		return NewLogfmtFormatter(), nil
+	case "human":
+		return NewHumanFormatter(), nil
+	case "template":
+		return NewTemplateFormatter(tmpl)
+	default:
+		return nil, fmt.Errorf("command: unknown format %q (want json, logfmt, human, or template)", name)
+	}
+}
+
+// JSONFormatter renders each envelope as compact jsonpb, matching the
+// gateway's own wire format. It is the default Formatter.
+type JSONFormatter struct {
+	marshaler jsonpb.Marshaler
+}
+
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+func (f *JSONFormatter) Format(e *loggregator_v2.Envelope) ([]byte, error) {
+	s, err := f.marshaler.MarshalToString(e)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// LogfmtFormatter renders each envelope as a flat line of key=value pairs,
+// drawn from the envelope's tags and its type-specific payload.
+type LogfmtFormatter struct{}
+
+func NewLogfmtFormatter() *LogfmtFormatter {
+	return &LogfmtFormatter{}
+}
+
+func (f *LogfmtFormatter) Format(e *loggregator_v2.Envelope) ([]byte, error) {
+	pairs := []string{
+		fmt.Sprintf("timestamp=%d", e.GetTimestamp()),
+		fmt.Sprintf("source_id=%s", e.GetSourceId()),
+	}
+	if e.GetInstanceId() != "" {
+		pairs = append(pairs, fmt.Sprintf("instance_id=%s", e.GetInstanceId()))
+	}
+
+	switch msg := e.GetMessage().(type) {
+	case *loggregator_v2.Envelope_Log:
+		pairs = append(pairs, "type=log", fmt.Sprintf("message=%q", string(msg.Log.GetPayload())))
+	case *loggregator_v2.Envelope_Counter:
+		pairs = append(pairs, "type=counter",
+			fmt.Sprintf("name=%s", msg.Counter.GetName()),
+			fmt.Sprintf("delta=%d", msg.Counter.GetDelta()),
+			fmt.Sprintf("total=%d", msg.Counter.GetTotal()))
+	case *loggregator_v2.Envelope_Gauge:
+		pairs = append(pairs, "type=gauge")
+		pairs = append(pairs, gaugePairs(msg.Gauge, "")...)
+	case *loggregator_v2.Envelope_Timer:
+		pairs = append(pairs, "type=timer",
+			fmt.Sprintf("name=%s", msg.Timer.GetName()),
+			fmt.Sprintf("start=%d", msg.Timer.GetStart()),
+			fmt.Sprintf("stop=%d", msg.Timer.GetStop()))
+	case *loggregator_v2.Envelope_Event:
+		pairs = append(pairs, "type=event",
+			fmt.Sprintf("title=%q", msg.Event.GetTitle()),
+			fmt.Sprintf("body=%q", msg.Event.GetBody()))
+	}
+
+	pairs = append(pairs, tagPairs(e.GetTags())...)
+
+	return []byte(strings.Join(pairs, " ")), nil
+}
+
+// gaugePairs renders each metric as "name=value<unitSep>unit". Logfmt wants
+// the unit packed onto the value with no separator so each pair stays a
+// single whitespace-delimited token; HumanFormatter wants a space so the
+// unit reads naturally, e.g. "cpu=42 percentage".
+func gaugePairs(gauge *loggregator_v2.Gauge, unitSep string) []string {
+	names := make([]string, 0, len(gauge.GetMetrics()))
+	for name := range gauge.GetMetrics() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		metric := gauge.GetMetrics()[name]
+		pairs = append(pairs, fmt.Sprintf("%s=%g%s%s", name, metric.GetValue(), unitSep, metric.GetUnit()))
+	}
+	return pairs
+}
+
+func tagPairs(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("tag.%s=%s", name, tags[name]))
+	}
+	return pairs
+}
+
+// HumanFormatter renders a colored, timestamped line similar to `cf logs`:
+// a "<timestamp> [source-id/instance-id]" prefix followed by a
+// type-specific, human readable body.
+type HumanFormatter struct {
+	NoColor bool
+}
+
+func NewHumanFormatter() *HumanFormatter {
+	return &HumanFormatter{}
+}
+
+const (
+	colorReset  = "\x1b[0m"
+	colorLog    = "\x1b[37m"
+	colorErrLog = "\x1b[31m"
+	colorGauge  = "\x1b[36m"
+	colorTimer  = "\x1b[35m"
+	colorCount  = "\x1b[33m"
+	colorEvent  = "\x1b[32m"
+)
+
+func (f *HumanFormatter) Format(e *loggregator_v2.Envelope) ([]byte, error) {
+	ts := time.Unix(0, e.GetTimestamp()).Format("2006-01-02T15:04:05.00-0700")
+	prefix := fmt.Sprintf("%s [%s/%s]", ts, e.GetSourceId(), e.GetInstanceId())
+
+	var color, body string
+	switch msg := e.GetMessage().(type) {
+	case *loggregator_v2.Envelope_Log:
+		color = colorLog
+		if msg.Log.GetType() == loggregator_v2.Log_ERR {
+			color = colorErrLog
+		}
+		body = string(msg.Log.GetPayload())
+	case *loggregator_v2.Envelope_Counter:
+		color = colorCount
+		body = fmt.Sprintf("%s delta=%d total=%d", msg.Counter.GetName(), msg.Counter.GetDelta(), msg.Counter.GetTotal())
+	case *loggregator_v2.Envelope_Gauge:
+		color = colorGauge
+		body = strings.Join(gaugePairs(msg.Gauge, " "), " ")
+	case *loggregator_v2.Envelope_Timer:
+		color = colorTimer
+		dur := time.Duration(msg.Timer.GetStop() - msg.Timer.GetStart())
+		body = fmt.Sprintf("%s %d->%d (%s)", msg.Timer.GetName(), msg.Timer.GetStart(), msg.Timer.GetStop(), dur)
+	case *loggregator_v2.Envelope_Event:
+		color = colorEvent
+		body = fmt.Sprintf("%s: %s", msg.Event.GetTitle(), msg.Event.GetBody())
+	}
+
+	line := fmt.Sprintf("%s %s", prefix, body)
+	if f.NoColor || color == "" {
+		return []byte(line), nil
+	}
+
+	return []byte(color + line + colorReset), nil
+}
+
+// TemplateFormatter renders each envelope through a user-supplied Go
+// text/template, e.g. `--format-template '{{.SourceId}}: {{.GetLog.Payload}}'`.
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+func NewTemplateFormatter(tmplText string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("format").Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf("command: invalid format template: %s", err)
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+func (f *TemplateFormatter) Format(e *loggregator_v2.Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
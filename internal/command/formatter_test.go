@@ -0,0 +1,98 @@
+package command_test
+
+import (
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Formatters", func() {
+	gauge := &loggregator_v2.Envelope{
+		SourceId: "some-source-id",
+		Message: &loggregator_v2.Envelope_Gauge{
+			Gauge: &loggregator_v2.Gauge{
+				Metrics: map[string]*loggregator_v2.GaugeValue{
+					"cpu": {Value: 42, Unit: "percentage"},
+				},
+			},
+		},
+	}
+
+	timer := &loggregator_v2.Envelope{
+		SourceId: "some-source-id",
+		Message: &loggregator_v2.Envelope_Timer{
+			Timer: &loggregator_v2.Timer{
+				Name:  "http",
+				Start: 100,
+				Stop:  250,
+			},
+		},
+	}
+
+	Describe("LogfmtFormatter", func() {
+		It("flattens a gauge envelope into key=value pairs", func() {
+			out, err := command.NewLogfmtFormatter().Format(gauge)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(Equal(
+				"timestamp=0 source_id=some-source-id type=gauge cpu=42percentage"))
+		})
+	})
+
+	Describe("HumanFormatter", func() {
+		It("renders a timer as name start->stop (dur)", func() {
+			f := command.NewHumanFormatter()
+			f.NoColor = true
+
+			out, err := f.Format(timer)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("http 100->250 (150ns)"))
+		})
+
+		It("renders a gauge as name=value unit", func() {
+			f := command.NewHumanFormatter()
+			f.NoColor = true
+
+			out, err := f.Format(gauge)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring("cpu=42 percentage"))
+		})
+	})
+
+	Describe("TemplateFormatter", func() {
+		It("renders envelope fields through the user-supplied template", func() {
+			f, err := command.NewTemplateFormatter("{{.SourceId}}")
+			Expect(err).ToNot(HaveOccurred())
+
+			out, err := f.Format(gauge)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(Equal("some-source-id"))
+		})
+
+		It("rejects an invalid template", func() {
+			_, err := command.NewTemplateFormatter("{{.Nope")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("NewFormatter", func() {
+		It("defaults to json", func() {
+			f, err := command.NewFormatter("", "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f).To(BeAssignableToTypeOf(command.NewJSONFormatter()))
+		})
+
+		It("errors on an unknown format", func() {
+			_, err := command.NewFormatter("yaml", "")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GatewayErrorKind classifies a GatewayError for exit-code mapping in the
+// plugin entrypoint.
+type GatewayErrorKind int
+
+const (
+	GatewayErrorUnknown GatewayErrorKind = iota
+	GatewayErrorAuth
+	GatewayErrorNotFound
+	GatewayErrorRateLimited
+	GatewayErrorServer
+)
+
+// GatewayError is returned by StreamLogs when the log-stream gateway
+// responds with a non-2xx status. RetryAfter, when non-zero, is how long
+// the gateway asked callers to wait before retrying (from a 429/503's
+// Retry-After header or JSON body).
+type GatewayError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+	RetryAfter time.Duration
+}
+
+func (e *GatewayError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("gateway: %s (status %d, request %s)", e.Message, e.StatusCode, e.RequestID)
+	}
+	return fmt.Sprintf("gateway: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// Kind classifies the error for exit-code mapping.
+func (e *GatewayError) Kind() GatewayErrorKind {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return GatewayErrorAuth
+	case e.StatusCode == http.StatusNotFound:
+		return GatewayErrorNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return GatewayErrorRateLimited
+	case e.StatusCode >= 500:
+		return GatewayErrorServer
+	default:
+		return GatewayErrorUnknown
+	}
+}
+
+type gatewayErrorBody struct {
+	Message    string `json:"message"`
+	RequestID  string `json:"request_id"`
+	RetryAfter int    `json:"retry_after"`
+}
+
+// parseGatewayError builds a GatewayError from a non-2xx response's status,
+// headers, and already-read body. Malformed or non-JSON bodies still
+// produce a GatewayError, just with the raw body as Message.
+func parseGatewayError(resp *http.Response, body []byte) *GatewayError {
+	var parsed gatewayErrorBody
+	_ = json.Unmarshal(body, &parsed)
+
+	message := parsed.Message
+	if message == "" {
+		message = string(body)
+	}
+
+	retryAfter := time.Duration(parsed.RetryAfter) * time.Second
+	if header := resp.Header.Get("Retry-After"); header != "" {
+		if secs, err := strconv.Atoi(header); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+	}
+
+	return &GatewayError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		RequestID:  parsed.RequestID,
+		RetryAfter: retryAfter,
+	}
+}
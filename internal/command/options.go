@@ -0,0 +1,116 @@
+package command
+
+import (
+	"time"
+
+	"github.com/cloudfoundry/log-stream-cli/internal/aggregator"
+	"github.com/cloudfoundry/log-stream-cli/internal/filter"
+)
+
+// config accumulates the optional behavior of StreamLogs. It is built up by
+// applying each Option in order, so later options win when they conflict.
+type config struct {
+	sourceIDs   []string
+	metricTypes []string
+	shardID     string
+
+	transport Transport
+	rlpClient RLPClient
+
+	since     time.Duration
+	lines     int
+	reconnect ReconnectPolicy
+
+	formatter Formatter
+
+	filterExpr *filter.Expr
+	filterErr  error
+
+	aggregateWindow time.Duration
+	aggregateFuncs  []aggregator.Func
+	aggSink         *aggregateSink
+
+	quietErrors bool
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		transport: TransportHTTP,
+		reconnect: DefaultReconnectPolicy,
+		formatter: NewJSONFormatter(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Option configures StreamLogs.
+type Option func(*config)
+
+// WithSourceIDs filters the stream to the given application or source GUIDs.
+// Entries that match an application name known to the AppProvider are
+// resolved to that application's guid.
+func WithSourceIDs(ids []string) Option {
+	return func(c *config) {
+		c.sourceIDs = ids
+	}
+}
+
+// WithMetricTypes filters the stream to the given envelope types (log,
+// counter, gauge, timer, event). If not supplied, all types are streamed.
+func WithMetricTypes(types []string) Option {
+	return func(c *config) {
+		c.metricTypes = types
+	}
+}
+
+// WithShardID splits delivery of the stream across every client that shares
+// the same shard ID, so that only one of them receives any given envelope.
+func WithShardID(id string) Option {
+	return func(c *config) {
+		c.shardID = id
+	}
+}
+
+// WithSince backfills envelopes from the given duration in the past before
+// tailing continues live.
+func WithSince(d time.Duration) Option {
+	return func(c *config) {
+		c.since = d
+	}
+}
+
+// WithLines backfills the most recent n envelopes before tailing continues
+// live.
+func WithLines(n int) Option {
+	return func(c *config) {
+		c.lines = n
+	}
+}
+
+// WithFilterExpr parses expr (the log-stream-cli filter DSL, e.g.
+// `source_id="app-guid" AND type IN (gauge,timer) AND tag.deployment="prod"`)
+// and uses it in place of WithSourceIDs/WithMetricTypes to select the
+// stream. Its source_id, type, and tag.<name> predicates restrict what is
+// written; instance_id predicates are parsed but do not restrict the
+// stream. A malformed expr is surfaced as StreamLogs' return value rather
+// than here, since Option cannot itself fail.
+func WithFilterExpr(expr string) Option {
+	return func(c *config) {
+		parsed, err := filter.Parse(expr)
+		if err != nil {
+			c.filterErr = err
+			return
+		}
+		c.filterExpr = parsed
+	}
+}
+
+// WithQuietErrors suppresses writing a gateway error's body to the terminal
+// writer; the error is still returned from StreamLogs as a *GatewayError.
+func WithQuietErrors(quiet bool) Option {
+	return func(c *config) {
+		c.quietErrors = quiet
+	}
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy controls how streamHTTP recovers when the gateway
+// connection drops (EOF) or responds with a 5xx: it waits an exponentially
+// growing, jittered backoff and then reconnects, resuming from the last
+// envelope seen.
+type ReconnectPolicy struct {
+	// MinBackoff is the delay before the first reconnect attempt. Defaults
+	// to 500ms if zero.
+	MinBackoff time.Duration
+	// MaxBackoff caps the computed backoff between attempts. Defaults to 30s
+	// if zero. A Retry-After from the gateway replaces the backoff and is
+	// not capped.
+	MaxBackoff time.Duration
+	// MaxRetries bounds the number of consecutive reconnect attempts that
+	// deliver no envelopes. Zero means retry forever, which is the default
+	// tailing behavior.
+	MaxRetries int
+}
+
+// DefaultReconnectPolicy retries forever with backoff between 500ms and
+// 30s.
+var DefaultReconnectPolicy = ReconnectPolicy{
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// WithReconnect overrides the backoff policy used when the stream
+// connection drops. Without this option, DefaultReconnectPolicy is used.
+func WithReconnect(policy ReconnectPolicy) Option {
+	return func(c *config) {
+		c.reconnect = policy
+	}
+}
+
+func (p ReconnectPolicy) exhausted(attempt int) bool {
+	return p.MaxRetries > 0 && attempt >= p.MaxRetries
+}
+
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	minBackoff := p.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultReconnectPolicy.MinBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultReconnectPolicy.MaxBackoff
+	}
+
+	backoff := float64(minBackoff) * math.Pow(2, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+
+	jittered := backoff * (0.5 + rand.Float64()*0.5)
+	return time.Duration(jittered)
+}
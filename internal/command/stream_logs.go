@@ -0,0 +1,283 @@
+// Package command implements the log-stream-cli plugin's streaming
+// behavior: resolving app names to source IDs, building the gateway/RLP
+// request, and writing out received envelopes.
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/gogo/protobuf/jsonpb"
+)
+
+var allMetricTypes = []string{"log", "counter", "gauge", "timer", "event"}
+
+// Client issues the HTTP request to the log-stream gateway.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// AppProvider resolves application names to guids so that StreamLogs can
+// accept either in --source-id.
+type AppProvider interface {
+	GetApps() ([]plugin_models.GetAppsModel, error)
+}
+
+// StreamLogs opens a stream against the log-stream gateway (or, with
+// WithTransport(TransportGRPC), directly against Loggregator RLP) and writes
+// received envelopes to writer. The gRPC transport returns when its stream
+// ends or errors; the HTTP transport reconnects when its stream ends and
+// returns on an error or once its ReconnectPolicy is exhausted.
+func StreamLogs(gatewayAddr string, client Client, appProvider AppProvider, writer io.Writer, opts ...Option) error {
+	cfg := newConfig(opts)
+	if cfg.filterErr != nil {
+		return cfg.filterErr
+	}
+
+	sourceIDs := cfg.sourceIDs
+	if cfg.filterExpr != nil {
+		sourceIDs = cfg.filterExpr.SourceIDs()
+		cfg.metricTypes = cfg.filterExpr.MetricTypes()
+	}
+	sourceIDs = resolveSourceIDs(sourceIDs, appProvider)
+
+	if cfg.aggregateWindow > 0 {
+		cfg.aggSink = newAggregateSink(cfg, writer)
+		defer cfg.aggSink.Close()
+	}
+
+	if cfg.transport == TransportGRPC {
+		return streamGRPC(cfg, sourceIDs, writer)
+	}
+
+	return streamHTTP(gatewayAddr, client, sourceIDs, cfg, writer)
+}
+
+// streamHTTP tails the gateway, reconnecting with cfg.reconnect's backoff
+// policy whenever the connection drops (EOF) or the gateway returns a 5xx.
+// Each reconnect resumes from the timestamp of the last envelope read so
+// the client doesn't see a gap or a flood of duplicates.
+func streamHTTP(gatewayAddr string, client Client, sourceIDs []string, cfg *config, writer io.Writer) error {
+	startTime := sinceStartTime(cfg.since)
+
+	attempt := 0
+	for {
+		req, err := buildHTTPRequest(gatewayAddr, sourceIDs, cfg, startTime)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			gwErr := readGatewayError(resp, writer, cfg)
+			if cfg.reconnect.exhausted(attempt) {
+				return gwErr
+			}
+			attempt++
+			wait := cfg.reconnect.backoff(attempt)
+			if gwErr.RetryAfter > 0 {
+				wait = gwErr.RetryAfter
+			}
+			time.Sleep(wait)
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return readGatewayError(resp, writer, cfg)
+		}
+
+		lastSeen, streamErr := writeEnvelopes(resp.Body, writer, cfg)
+		resp.Body.Close()
+		if lastSeen != 0 {
+			startTime = lastSeen + 1
+			attempt = 0
+		}
+
+		if streamErr != nil {
+			return streamErr
+		}
+
+		// A clean EOF on a 2xx stream means the connection was dropped;
+		// reconnect and resume from startTime. A stream that delivered
+		// envelopes resets the attempt count; an empty one counts as an
+		// attempt toward cfg.reconnect.MaxRetries.
+		if cfg.reconnect.exhausted(attempt) {
+			return nil
+		}
+		attempt++
+		time.Sleep(cfg.reconnect.backoff(attempt))
+	}
+}
+
+// readGatewayError drains resp's body, parses it into a *GatewayError, and
+// (unless cfg.quietErrors) writes the raw body to writer so the error is
+// still visible on the terminal.
+func readGatewayError(resp *http.Response, writer io.Writer, cfg *config) *GatewayError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !cfg.quietErrors {
+		writer.Write(body)
+	}
+
+	return parseGatewayError(resp, body)
+}
+
+func buildHTTPRequest(gatewayAddr string, sourceIDs []string, cfg *config, startTime int64) (*http.Request, error) {
+	u, err := url.Parse(gatewayAddr)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = buildQuery(sourceIDs, cfg, startTime).Encode()
+
+	return http.NewRequest(http.MethodGet, u.String(), nil)
+}
+
+func buildQuery(sourceIDs []string, cfg *config, startTime int64) url.Values {
+	q := url.Values{}
+
+	types := cfg.metricTypes
+	if len(types) == 0 {
+		types = allMetricTypes
+	}
+	for _, t := range types {
+		q.Add(t, "")
+	}
+
+	for _, id := range sourceIDs {
+		q.Add("source_id", id)
+	}
+
+	if cfg.shardID != "" {
+		q.Set("shard_id", cfg.shardID)
+	}
+
+	if startTime != 0 {
+		q.Set("start_time", strconv.FormatInt(startTime, 10))
+	}
+
+	if cfg.lines > 0 {
+		q.Set("lines", strconv.Itoa(cfg.lines))
+	}
+
+	return q
+}
+
+func sinceStartTime(since time.Duration) int64 {
+	if since <= 0 {
+		return 0
+	}
+	return time.Now().Add(-since).UnixNano()
+}
+
+func resolveSourceIDs(ids []string, appProvider AppProvider) []string {
+	if len(ids) == 0 {
+		return ids
+	}
+
+	apps, err := appProvider.GetApps()
+	if err != nil {
+		return ids
+	}
+
+	guidByName := make(map[string]string, len(apps))
+	for _, app := range apps {
+		guidByName[app.Name] = app.Guid
+	}
+
+	resolved := make([]string, len(ids))
+	for i, id := range ids {
+		if guid, ok := guidByName[id]; ok {
+			resolved[i] = guid
+		} else {
+			resolved[i] = id
+		}
+	}
+
+	return resolved
+}
+
+// writeEnvelopes reads a server-sent-events body of jsonpb-encoded
+// EnvelopeBatch messages and writes each contained envelope, rendered by
+// formatter, one per line, to writer. It returns the timestamp of the last
+// envelope read (including envelopes skipped by the filter or folded into
+// the aggregator), or 0 if none were, so the caller can resume a dropped
+// connection without a gap.
+func writeEnvelopes(body io.Reader, writer io.Writer, cfg *config) (int64, error) {
+	reader := bufio.NewReader(body)
+
+	var event bytes.Buffer
+	var lastTimestamp int64
+	for {
+		line, readErr := reader.ReadString('\n')
+		event.WriteString(line)
+
+		if strings.HasSuffix(event.String(), "\n\n") {
+			ts, err := writeEvent(event.String(), writer, cfg)
+			if err != nil {
+				return lastTimestamp, err
+			}
+			if ts != 0 {
+				lastTimestamp = ts
+			}
+			event.Reset()
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				return lastTimestamp, nil
+			}
+			return lastTimestamp, readErr
+		}
+	}
+}
+
+func writeEvent(event string, writer io.Writer, cfg *config) (int64, error) {
+	event = strings.TrimSpace(event)
+	if !strings.HasPrefix(event, "data: ") {
+		return 0, nil
+	}
+	payload := strings.TrimPrefix(event, "data: ")
+
+	var batch loggregator_v2.EnvelopeBatch
+	if err := jsonpb.UnmarshalString(payload, &batch); err != nil {
+		return 0, err
+	}
+
+	var lastTimestamp int64
+	for _, e := range batch.Batch {
+		lastTimestamp = e.GetTimestamp()
+
+		if cfg.filterExpr != nil && !cfg.filterExpr.MatchesTags(e.GetTags()) {
+			continue
+		}
+
+		if cfg.aggSink != nil && cfg.aggSink.handle(e) {
+			continue
+		}
+
+		out, err := cfg.formatter.Format(e)
+		if err != nil {
+			return lastTimestamp, err
+		}
+		if _, err := fmt.Fprintf(writer, "%s\n", out); err != nil {
+			return lastTimestamp, err
+		}
+	}
+
+	return lastTimestamp, nil
+}
@@ -0,0 +1,61 @@
+package command_test
+
+import (
+	"bytes"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/aggregator"
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamLogs with WithAggregate", func() {
+	It("rolls gauge envelopes up into periodic avg envelopes instead of writing them raw", func() {
+		writer := &syncedWriter{buf: bytes.NewBuffer([]byte{})}
+		rlp := &fakeRLPClient{
+			stream: &fakeBatchedReceiverClient{
+				batches: []*loggregator_v2.EnvelopeBatch{
+					{Batch: []*loggregator_v2.Envelope{
+						{SourceId: "app", Message: &loggregator_v2.Envelope_Gauge{
+							Gauge: &loggregator_v2.Gauge{
+								Metrics: map[string]*loggregator_v2.GaugeValue{
+									"cpu": {Value: 10, Unit: "percentage"},
+								},
+							},
+						}},
+					}},
+				},
+			},
+		}
+
+		go command.StreamLogs(
+			"unused-for-grpc",
+			nil,
+			&fakeAppProvider{},
+			writer,
+			command.WithTransport(command.TransportGRPC),
+			command.WithRLPClient(rlp),
+			command.WithAggregate(20*time.Millisecond, []aggregator.Func{aggregator.FuncAvg}),
+		)
+
+		Eventually(writer.String, time.Second).Should(ContainSubstring("cpu.avg"))
+		Expect(writer.String()).ToNot(ContainSubstring(`"cpu":`))
+	})
+
+	It("parses the --aggregate flag shape", func() {
+		window, funcs, err := command.ParseAggregateFlag("10s:avg,p99")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(window).To(Equal(10 * time.Second))
+		Expect(funcs).To(Equal([]aggregator.Func{aggregator.FuncAvg, aggregator.FuncP99}))
+	})
+
+	It("rejects a malformed --aggregate flag", func() {
+		_, _, err := command.ParseAggregateFlag("not-valid")
+
+		Expect(err).To(HaveOccurred())
+	})
+})
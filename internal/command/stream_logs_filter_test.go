@@ -0,0 +1,53 @@
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newEmptyOKResponse() *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+	}
+}
+
+var _ = Describe("StreamLogs with WithFilterExpr", func() {
+	It("builds the query from the filter expression's source_id and type predicates", func() {
+		fc := &fakeClient{
+			response: newEmptyOKResponse(),
+		}
+
+		go command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			fc,
+			&fakeAppProvider{},
+			&syncedWriter{buf: bytes.NewBuffer([]byte{})},
+			command.WithFilterExpr(`source_id="app-guid" AND type IN (gauge,timer)`),
+		)
+
+		Eventually(fc.Query).Should(HaveKeyWithValue("source_id", []string{"app-guid"}))
+		Eventually(fc.Query).Should(HaveKeyWithValue("gauge", []string{""}))
+		Eventually(fc.Query).Should(HaveKeyWithValue("timer", []string{""}))
+	})
+
+	It("surfaces a malformed filter expression as the returned error", func() {
+		fc := &fakeClient{response: newEmptyOKResponse()}
+
+		err := command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			fc,
+			&fakeAppProvider{},
+			&syncedWriter{buf: bytes.NewBuffer([]byte{})},
+			command.WithFilterExpr(`color="blue"`),
+		)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,125 @@
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+	"google.golang.org/grpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamLogs over gRPC", func() {
+	var (
+		writer *syncedWriter
+		rlp    *fakeRLPClient
+	)
+
+	BeforeEach(func() {
+		writer = &syncedWriter{buf: bytes.NewBuffer([]byte{})}
+		rlp = &fakeRLPClient{}
+	})
+
+	It("opens a BatchedReceiver stream built from the source id and type filters", func() {
+		go command.StreamLogs(
+			"unused-for-grpc",
+			nil,
+			&fakeAppProvider{},
+			writer,
+			command.WithTransport(command.TransportGRPC),
+			command.WithRLPClient(rlp),
+			command.WithSourceIDs([]string{"some-source-id"}),
+			command.WithMetricTypes([]string{"gauge"}),
+		)
+
+		Eventually(rlp.Request).ShouldNot(BeNil())
+		Expect(rlp.Request().Selectors).To(HaveLen(1))
+		Expect(rlp.Request().Selectors[0].SourceId).To(Equal("some-source-id"))
+		Expect(rlp.Request().Selectors[0].GetGauge()).ToNot(BeNil())
+	})
+
+	It("writes envelopes received over the stream", func() {
+		envelope := &loggregator_v2.Envelope{
+			Message: &loggregator_v2.Envelope_Log{
+				Log: &loggregator_v2.Log{Payload: []byte("hello, world")},
+			},
+		}
+		rlp.stream = &fakeBatchedReceiverClient{
+			batches: []*loggregator_v2.EnvelopeBatch{
+				{Batch: []*loggregator_v2.Envelope{envelope}},
+			},
+		}
+
+		go command.StreamLogs(
+			"unused-for-grpc",
+			nil,
+			&fakeAppProvider{},
+			writer,
+			command.WithTransport(command.TransportGRPC),
+			command.WithRLPClient(rlp),
+		)
+
+		Eventually(writer.String).Should(Equal("{\"log\":{\"payload\":\"hello, world\"}}\n"))
+	})
+
+	It("errors when no RLPClient is configured", func() {
+		err := command.StreamLogs(
+			"unused-for-grpc",
+			nil,
+			&fakeAppProvider{},
+			writer,
+			command.WithTransport(command.TransportGRPC),
+		)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type fakeRLPClient struct {
+	request *loggregator_v2.EgressBatchRequest
+	stream  loggregator_v2.Egress_BatchedReceiverClient
+	err     error
+
+	mu sync.Mutex
+}
+
+func (f *fakeRLPClient) Request() *loggregator_v2.EgressBatchRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.request
+}
+
+func (f *fakeRLPClient) BatchedReceiver(ctx context.Context, in *loggregator_v2.EgressBatchRequest, opts ...grpc.CallOption) (loggregator_v2.Egress_BatchedReceiverClient, error) {
+	f.mu.Lock()
+	f.request = in
+	f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.stream == nil {
+		return &fakeBatchedReceiverClient{}, nil
+	}
+	return f.stream, nil
+}
+
+type fakeBatchedReceiverClient struct {
+	loggregator_v2.Egress_BatchedReceiverClient
+	batches []*loggregator_v2.EnvelopeBatch
+	i       int
+}
+
+func (f *fakeBatchedReceiverClient) Recv() (*loggregator_v2.EnvelopeBatch, error) {
+	if f.i >= len(f.batches) {
+		return nil, io.EOF
+	}
+	b := f.batches[f.i]
+	f.i++
+	return b, nil
+}
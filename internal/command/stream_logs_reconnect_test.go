@@ -0,0 +1,157 @@
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/log-stream-cli/internal/command"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamLogs reconnect", func() {
+	var (
+		writer *syncedWriter
+		rc     *reconnectingClient
+	)
+
+	BeforeEach(func() {
+		writer = &syncedWriter{buf: bytes.NewBuffer([]byte{})}
+		rc = &reconnectingClient{
+			bodies: []string{
+				"data: {\"batch\":[{\"timestamp\":\"1000\",\"log\":{\"payload\":\"aGVsbG8=\"}}]}\n\n",
+				"data: {\"batch\":[{\"timestamp\":\"2000\",\"log\":{\"payload\":\"d29ybGQ=\"}}]}\n\n",
+			},
+		}
+	})
+
+	It("reconnects with an advancing start_time query param after each dropped connection", func() {
+		go command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			rc,
+			&fakeAppProvider{},
+			writer,
+			command.WithReconnect(command.ReconnectPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		)
+
+		Eventually(rc.QueryCount).Should(BeNumerically(">=", 2))
+
+		queries := rc.Queries()
+		Expect(queries[0].Get("start_time")).To(Equal(""))
+		Expect(queries[1].Get("start_time")).To(Equal("1001"))
+	})
+
+	It("honors a Retry-After header over the configured backoff on a 429", func() {
+		tc := &throttlingClient{
+			statusCodes: []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:  "1",
+		}
+
+		start := time.Now()
+		go command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			tc,
+			&fakeAppProvider{},
+			writer,
+			command.WithReconnect(command.ReconnectPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}),
+		)
+
+		Eventually(tc.CallCount, 2*time.Second).Should(Equal(2))
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+	})
+})
+
+// throttlingClient returns the given statusCodes in order (repeating the
+// last one once exhausted), always with a Retry-After header set to
+// retryAfter, simulating a gateway that rate-limits the first N requests.
+// A 2xx response's body never ends, like a live tail, so no further calls
+// follow it.
+type throttlingClient struct {
+	statusCodes []int
+	retryAfter  string
+	calls       int
+
+	mu sync.Mutex
+}
+
+func (c *throttlingClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.calls
+	if idx >= len(c.statusCodes) {
+		idx = len(c.statusCodes) - 1
+	}
+	status := c.statusCodes[idx]
+	c.calls++
+
+	header := http.Header{}
+	header.Set("Retry-After", c.retryAfter)
+
+	body := ioutil.NopCloser(bytes.NewBufferString(""))
+	if status >= 200 && status < 300 {
+		body = ioutil.NopCloser(channelReader(make(chan []byte)))
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       body,
+	}, nil
+}
+
+func (c *throttlingClient) CallCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls
+}
+
+// reconnectingClient serves one prepared body per call to Do, simulating a
+// gateway connection that drops (EOF) after each response.
+type reconnectingClient struct {
+	bodies []string
+	calls  int
+
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+func (c *reconnectingClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queries = append(c.queries, req.URL.Query())
+
+	body := ""
+	if c.calls < len(c.bodies) {
+		body = c.bodies[c.calls]
+	}
+	c.calls++
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}, nil
+}
+
+func (c *reconnectingClient) QueryCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.queries)
+}
+
+func (c *reconnectingClient) Queries() []url.Values {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]url.Values, len(c.queries))
+	copy(out, c.queries)
+	return out
+}
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
 	"github.com/cloudfoundry/log-stream-cli/internal/command"
@@ -133,6 +134,30 @@ var _ = Describe("StreamLogs", func() {
 		Eventually(fc.Query).Should(HaveKeyWithValue("shard_id", []string{"tralala"}))
 	})
 
+	It("backfills from a duration in the past via --since", func() {
+		go command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			fc,
+			appPovider,
+			writer,
+			command.WithSince(time.Minute),
+		)
+
+		Eventually(fc.Query).Should(HaveKey("start_time"))
+	})
+
+	It("backfills a fixed number of lines via --lines", func() {
+		go command.StreamLogs(
+			"https://log-stream.test-minster.cf-app.com",
+			fc,
+			appPovider,
+			writer,
+			command.WithLines(50),
+		)
+
+		Eventually(fc.Query).Should(HaveKeyWithValue("lines", []string{"50"}))
+	})
+
 	It("requests app id when app name is given", func() {
 		appPovider.apps = []plugin_models.GetAppsModel{
 			{Name: "app-name", Guid: "app-guid"},
@@ -163,14 +188,29 @@ var _ = Describe("StreamLogs", func() {
 	})
 
 	Context("when there is an error", func() {
-		It("writes the error", func() {
+		BeforeEach(func() {
 			fc.response.Body = ioutil.NopCloser(strings.NewReader(`{"message": "there was an error"}`))
 			fc.response.StatusCode = http.StatusNotFound
+		})
+
+		It("returns a structured GatewayError and writes the error body", func() {
+			err := command.StreamLogs("https://log-stream.test-minster.cf-app.com", fc, appPovider, writer)
 
-			go command.StreamLogs("https://log-stream.test-minster.cf-app.com", fc, appPovider, writer)
+			gwErr, ok := err.(*command.GatewayError)
+			Expect(ok).To(BeTrue())
+			Expect(gwErr.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(gwErr.Message).To(Equal("there was an error"))
+			Expect(gwErr.Kind()).To(Equal(command.GatewayErrorNotFound))
 
 			Eventually(writer.String).Should(ContainSubstring(`{"message": "there was an error"}`))
 		})
+
+		It("writes no bytes to the terminal when --quiet-errors is set", func() {
+			err := command.StreamLogs("https://log-stream.test-minster.cf-app.com", fc, appPovider, writer, command.WithQuietErrors(true))
+
+			Expect(err).To(HaveOccurred())
+			Expect(writer.String()).To(BeEmpty())
+		})
 	})
 })
 
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/cloudfoundry/log-stream-cli/internal/log_stream_plugin"
+)
+
+// Transport selects which backend StreamLogs talks to.
+type Transport int
+
+const (
+	// TransportHTTP streams from the log-stream HTTP/SSE gateway. This is
+	// the default.
+	TransportHTTP Transport = iota
+	// TransportGRPC streams directly from Loggregator RLP's gRPC
+	// Egress.BatchedReceiver, bypassing the gateway.
+	TransportGRPC
+)
+
+// WithTransport selects the transport StreamLogs uses. TransportGRPC
+// requires WithRLPClient to also be supplied.
+func WithTransport(t Transport) Option {
+	return func(c *config) {
+		c.transport = t
+	}
+}
+
+// WithRLPClient supplies the RLP client used when the transport is
+// TransportGRPC.
+func WithRLPClient(rlpClient RLPClient) Option {
+	return func(c *config) {
+		c.rlpClient = rlpClient
+	}
+}
+
+// RLPClient is the subset of the generated loggregator_v2 Egress client that
+// StreamLogs needs, narrowed so that tests can provide a fake in place of a
+// real gRPC connection.
+type RLPClient interface {
+	BatchedReceiver(ctx context.Context, in *loggregator_v2.EgressBatchRequest, opts ...grpc.CallOption) (loggregator_v2.Egress_BatchedReceiverClient, error)
+}
+
+// NewRLPClient dials Loggregator RLP directly over mTLS using the given
+// client cert/key and CA, and returns an RLPClient backed by that
+// connection.
+func NewRLPClient(addr, certFile, keyFile, caFile string) (RLPClient, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load RLP client cert/key: %s", err)
+	}
+
+	caCert, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RLP CA cert: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("failed to parse RLP CA cert")
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   "reverselogproxy",
+	})
+
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial RLP at %s: %s", addr, err)
+	}
+
+	return loggregator_v2.NewEgressClient(conn), nil
+}
+
+func streamGRPC(cfg *config, sourceIDs []string, writer io.Writer) error {
+	if cfg.rlpClient == nil {
+		return errors.New("command: TransportGRPC requires WithRLPClient")
+	}
+
+	req, err := log_stream_plugin.MakeRequest(sourceIDs, cfg.metricTypes)
+	if err != nil {
+		return err
+	}
+
+	stream, err := cfg.rlpClient.BatchedReceiver(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		batch, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, e := range batch.Batch {
+			if cfg.filterExpr != nil && !cfg.filterExpr.MatchesTags(e.GetTags()) {
+				continue
+			}
+
+			if cfg.aggSink != nil && cfg.aggSink.handle(e) {
+				continue
+			}
+
+			out, err := cfg.formatter.Format(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(writer, "%s\n", out); err != nil {
+				return err
+			}
+		}
+	}
+}
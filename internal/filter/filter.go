@@ -0,0 +1,83 @@
+// Package filter parses the log-stream-cli filter expression language,
+// e.g. `source_id="app-guid" AND type IN (gauge,timer) AND tag.deployment="prod"`,
+// into an Expr that command and log_stream_plugin can compile into gateway
+// query params or RLP selectors.
+package filter
+
+import "strings"
+
+// Predicate is a single clause of a filter expression. Values holds one
+// entry for an equality predicate (field="value") and many for an IN (...)
+// set.
+type Predicate struct {
+	Field  string
+	Values []string
+}
+
+// Expr is a parsed filter expression. Every Predicate must hold (AND) for
+// an envelope to match.
+type Expr struct {
+	Predicates []Predicate
+}
+
+// Parse parses a filter expression, returning a *ParseError naming the
+// offending token and column if expr is malformed.
+func Parse(expr string) (*Expr, error) {
+	return newParser(expr).parse()
+}
+
+// SourceIDs returns the values of any source_id predicates, in order.
+func (e *Expr) SourceIDs() []string {
+	return e.valuesFor("source_id")
+}
+
+// MetricTypes returns the values of the type predicate, if any.
+func (e *Expr) MetricTypes() []string {
+	return e.valuesFor("type")
+}
+
+// InstanceIDs returns the values of any instance_id predicates, in order.
+func (e *Expr) InstanceIDs() []string {
+	return e.valuesFor("instance_id")
+}
+
+// Tags returns the tag.<name>="value" predicates as a name->value map.
+func (e *Expr) Tags() map[string]string {
+	tags := map[string]string{}
+	for _, p := range e.Predicates {
+		if name, ok := tagName(p.Field); ok && len(p.Values) > 0 {
+			tags[name] = p.Values[0]
+		}
+	}
+	return tags
+}
+
+// MatchesTags reports whether envelopeTags satisfies every tag predicate in
+// the expression. It is used to post-filter envelopes whose transport
+// (e.g. RLP selectors) has no native concept of tag filtering.
+func (e *Expr) MatchesTags(envelopeTags map[string]string) bool {
+	for name, want := range e.Tags() {
+		if envelopeTags[name] != want {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expr) valuesFor(field string) []string {
+	var values []string
+	for _, p := range e.Predicates {
+		if p.Field == field {
+			values = append(values, p.Values...)
+		}
+	}
+	return values
+}
+
+func tagName(field string) (string, bool) {
+	const prefix = "tag."
+	if !strings.HasPrefix(field, prefix) || field == prefix {
+		return "", false
+	}
+	return strings.TrimPrefix(field, prefix), true
+}
@@ -0,0 +1,69 @@
+package filter_test
+
+import (
+	"github.com/cloudfoundry/log-stream-cli/internal/filter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse", func() {
+	It("parses a conjunction of source_id, type IN (...), and tag equality", func() {
+		expr, err := filter.Parse(`source_id="app-guid" AND type IN (gauge,timer) AND tag.deployment="prod"`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr.SourceIDs()).To(Equal([]string{"app-guid"}))
+		Expect(expr.MetricTypes()).To(Equal([]string{"gauge", "timer"}))
+		Expect(expr.Tags()).To(Equal(map[string]string{"deployment": "prod"}))
+	})
+
+	It("parses instance_id equality", func() {
+		expr, err := filter.Parse(`instance_id="0"`)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expr.InstanceIDs()).To(Equal([]string{"0"}))
+	})
+
+	It("returns a structured error naming the offending token and column", func() {
+		_, err := filter.Parse(`color="blue"`)
+
+		Expect(err).To(HaveOccurred())
+		parseErr, ok := err.(*filter.ParseError)
+		Expect(ok).To(BeTrue())
+		Expect(parseErr.Token).To(Equal("color"))
+		Expect(parseErr.Column).To(Equal(1))
+	})
+
+	It("errors on an unterminated IN set", func() {
+		_, err := filter.Parse(`type IN (gauge`)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns a structured error naming an invalid type IN (...) value", func() {
+		_, err := filter.Parse(`type IN (gauge,nope)`)
+
+		Expect(err).To(HaveOccurred())
+		parseErr, ok := err.(*filter.ParseError)
+		Expect(ok).To(BeTrue())
+		Expect(parseErr.Token).To(Equal("nope"))
+		Expect(parseErr.Column).To(Equal(16))
+	})
+})
+
+var _ = Describe("Expr.MatchesTags", func() {
+	It("is satisfied when every tag predicate matches", func() {
+		expr, err := filter.Parse(`tag.deployment="prod" AND tag.az="z1"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(expr.MatchesTags(map[string]string{"deployment": "prod", "az": "z1"})).To(BeTrue())
+		Expect(expr.MatchesTags(map[string]string{"deployment": "staging", "az": "z1"})).To(BeFalse())
+	})
+
+	It("is satisfied trivially when there are no tag predicates", func() {
+		expr, err := filter.Parse(`source_id="app-guid"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(expr.MatchesTags(map[string]string{"anything": "goes"})).To(BeTrue())
+	})
+})
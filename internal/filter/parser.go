@@ -0,0 +1,267 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError names the offending token and its 1-based column so the CLI
+// can point the user at the exact spot in their --filter string.
+type ParseError struct {
+	Token  string
+	Column int
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("filter: %s at column %d: %q", e.Reason, e.Column, e.Token)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokEq
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, column: l.pos + 1}, nil
+	}
+
+	start := l.pos
+	c := l.input[l.pos]
+
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", column: start + 1}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", column: start + 1}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokComma, text: ",", column: start + 1}, nil
+	case c == '=':
+		l.pos++
+		return token{kind: tokEq, text: "=", column: start + 1}, nil
+	case c == '"':
+		return l.scanString()
+	case isIdentStart(c):
+		return l.scanIdent(), nil
+	default:
+		return token{}, &ParseError{Token: string(c), Column: start + 1, Reason: "unexpected character"}
+	}
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.input) && (l.input[l.pos] == ' ' || l.input[l.pos] == '\t') {
+		l.pos++
+	}
+}
+
+func (l *lexer) scanString() (token, error) {
+	start := l.pos
+	l.pos++ // opening quote
+	var b strings.Builder
+	for l.pos < len(l.input) && l.input[l.pos] != '"' {
+		b.WriteByte(l.input[l.pos])
+		l.pos++
+	}
+	if l.pos >= len(l.input) {
+		return token{}, &ParseError{Token: l.input[start:], Column: start + 1, Reason: "unterminated string"}
+	}
+	l.pos++ // closing quote
+	return token{kind: tokString, text: b.String(), column: start + 1}, nil
+}
+
+func (l *lexer) scanIdent() token {
+	start := l.pos
+	for l.pos < len(l.input) && isIdentPart(l.input[l.pos]) {
+		l.pos++
+	}
+	return token{kind: tokIdent, text: l.input[start:l.pos], column: start + 1}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '-'
+}
+
+type parser struct {
+	lex *lexer
+	cur token
+}
+
+func newParser(input string) *parser {
+	return &parser{lex: &lexer{input: input}}
+}
+
+func (p *parser) parse() (*Expr, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	expr := &Expr{}
+	for {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		expr.Predicates = append(expr.Predicates, pred)
+
+		if p.cur.kind == tokEOF {
+			return expr, nil
+		}
+
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.cur = tok
+	return nil
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if p.cur.kind != tokIdent || !strings.EqualFold(p.cur.text, kw) {
+		return &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: fmt.Sprintf("expected %s", kw)}
+	}
+	return nil
+}
+
+func (p *parser) parsePredicate() (Predicate, error) {
+	if p.cur.kind != tokIdent {
+		return Predicate{}, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected a field name"}
+	}
+	field := strings.ToLower(p.cur.text)
+	fieldCol := p.cur.column
+	if err := p.advance(); err != nil {
+		return Predicate{}, err
+	}
+
+	if field == "type" && p.cur.kind == tokIdent && strings.EqualFold(p.cur.text, "IN") {
+		if err := p.advance(); err != nil {
+			return Predicate{}, err
+		}
+		values, err := p.parseValueSet(isMetricType, "unknown metric type (want log, counter, gauge, timer, or event)")
+		if err != nil {
+			return Predicate{}, err
+		}
+		return Predicate{Field: "type", Values: values}, nil
+	}
+
+	if !isFilterableField(field) {
+		return Predicate{}, &ParseError{Token: field, Column: fieldCol, Reason: "unknown field"}
+	}
+
+	if p.cur.kind != tokEq {
+		return Predicate{}, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected ="}
+	}
+	if err := p.advance(); err != nil {
+		return Predicate{}, err
+	}
+
+	if p.cur.kind != tokString {
+		return Predicate{}, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected a quoted string"}
+	}
+	value := p.cur.text
+	if err := p.advance(); err != nil {
+		return Predicate{}, err
+	}
+
+	return Predicate{Field: field, Values: []string{value}}, nil
+}
+
+// parseValueSet parses a parenthesized, comma-separated list of identifiers.
+// Each value is lowercased and checked against valid; an invalid value
+// produces a *ParseError naming that exact token and column.
+func (p *parser) parseValueSet(valid func(string) bool, invalidReason string) ([]string, error) {
+	if p.cur.kind != tokLParen {
+		return nil, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected ("}
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for {
+		if p.cur.kind != tokIdent {
+			return nil, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected a value"}
+		}
+		value := strings.ToLower(p.cur.text)
+		if !valid(value) {
+			return nil, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: invalidReason}
+		}
+		values = append(values, value)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.cur.kind == tokComma {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		break
+	}
+
+	if p.cur.kind != tokRParen {
+		return nil, &ParseError{Token: p.cur.text, Column: p.cur.column, Reason: "expected )"}
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// isMetricType reports whether v is a valid value for a `type IN (...)`
+// predicate, matching the envelope types the gateway and RLP selectors know
+// about.
+func isMetricType(v string) bool {
+	switch v {
+	case "log", "counter", "gauge", "timer", "event":
+		return true
+	default:
+		return false
+	}
+}
+
+func isFilterableField(field string) bool {
+	if field == "source_id" || field == "instance_id" {
+		return true
+	}
+	name, ok := tagName(field)
+	return ok && name != ""
+}
@@ -0,0 +1,24 @@
+package log_stream_plugin_test
+
+import (
+	"github.com/cloudfoundry/log-stream-cli/internal/filter"
+	"github.com/cloudfoundry/log-stream-cli/internal/log_stream_plugin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MakeRequestFromFilter", func() {
+	It("builds selectors from the expression's source_id and type predicates", func() {
+		expr, err := filter.Parse(`source_id="foo" AND type IN (gauge,counter)`)
+		Expect(err).ToNot(HaveOccurred())
+
+		req, err := log_stream_plugin.MakeRequestFromFilter(expr)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Selectors).To(HaveLen(2))
+		Expect(req.Selectors[0].SourceId).To(Equal("foo"))
+		Expect(req.Selectors[0].GetGauge()).ToNot(BeNil())
+		Expect(req.Selectors[1].GetCounter()).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,82 @@
+// Package log_stream_plugin builds Loggregator RLP selector requests from the
+// CLI-facing source-id and metric-type filters.
+package log_stream_plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/go-loggregator/rpc/loggregator_v2"
+	"github.com/cloudfoundry/log-stream-cli/internal/filter"
+)
+
+var defaultMetricTypes = []string{"log", "counter", "event", "gauge", "timer"}
+
+// MakeRequest builds an EgressBatchRequest selecting the given source IDs and
+// metric types. An empty sourceIDs list selects all source IDs. An empty
+// metricTypes list selects all supported metric types.
+func MakeRequest(sourceIDs []string, metricTypes []string) (*loggregator_v2.EgressBatchRequest, error) {
+	types := metricTypes
+	if len(types) == 0 {
+		types = defaultMetricTypes
+	}
+
+	var invalid []string
+	for _, t := range types {
+		if !isValidMetricType(t) {
+			invalid = append(invalid, t)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid metric type(s): %s", strings.Join(invalid, ", "))
+	}
+
+	ids := sourceIDs
+	if len(ids) == 0 {
+		ids = []string{""}
+	}
+
+	var selectors []*loggregator_v2.Selector
+	for _, id := range ids {
+		for _, t := range types {
+			sel, _ := buildSelector(id, t)
+			selectors = append(selectors, sel)
+		}
+	}
+
+	return &loggregator_v2.EgressBatchRequest{Selectors: selectors}, nil
+}
+
+// MakeRequestFromFilter builds an EgressBatchRequest from a parsed filter
+// expression's source_id and type predicates. Tag predicates have no native
+// RLP selector equivalent and must be applied by the caller as a
+// client-side post-filter (see filter.Expr.MatchesTags).
+func MakeRequestFromFilter(expr *filter.Expr) (*loggregator_v2.EgressBatchRequest, error) {
+	return MakeRequest(expr.SourceIDs(), expr.MetricTypes())
+}
+
+func isValidMetricType(t string) bool {
+	_, ok := buildSelector("", t)
+	return ok
+}
+
+func buildSelector(sourceID, metricType string) (*loggregator_v2.Selector, bool) {
+	sel := &loggregator_v2.Selector{SourceId: sourceID}
+
+	switch metricType {
+	case "log":
+		sel.Message = &loggregator_v2.Selector_Log{Log: &loggregator_v2.LogSelector{}}
+	case "counter":
+		sel.Message = &loggregator_v2.Selector_Counter{Counter: &loggregator_v2.CounterSelector{}}
+	case "gauge":
+		sel.Message = &loggregator_v2.Selector_Gauge{Gauge: &loggregator_v2.GaugeSelector{}}
+	case "timer":
+		sel.Message = &loggregator_v2.Selector_Timer{Timer: &loggregator_v2.TimerSelector{}}
+	case "event":
+		sel.Message = &loggregator_v2.Selector_Event{Event: &loggregator_v2.EventSelector{}}
+	default:
+		return nil, false
+	}
+
+	return sel, true
+}